@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// startAdminServer 启动管理 HTTP 服务，ctx 取消时调用 server.Close() 立即关闭
+// （不等待进行中的请求完成）。
+func startAdminServer(ctx context.Context, addr string, rm *RuleManager, rulesDir string) {
+	server := &http.Server{Addr: addr, Handler: newAdminMux(rm, rulesDir)}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	go func() {
+		log.Printf("Admin HTTP server listening on %s", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Admin HTTP server error: %v", err)
+		}
+	}()
+}
+
+// adminServer 提供规则管理与可观测性相关的 HTTP 接口
+type adminServer struct {
+	rm       *RuleManager
+	rulesDir string
+}
+
+// newAdminMux 构造管理端点的路由：GET /rules、POST /rules/reload、
+// POST /rules/validate、GET /healthz、GET /metrics。
+func newAdminMux(rm *RuleManager, rulesDir string) *http.ServeMux {
+	s := &adminServer{rm: rm, rulesDir: rulesDir}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/rules", s.handleRules)
+	mux.HandleFunc("/rules/reload", s.handleReload)
+	mux.HandleFunc("/rules/validate", s.handleValidate)
+	return mux
+}
+
+func (s *adminServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+func (s *adminServer) handleRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.rm.Get())
+}
+
+func (s *adminServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.rm.Reload(); err != nil {
+		http.Error(w, fmt.Sprintf("failed to reload rules: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte("reloaded"))
+}
+
+// resolveRulesFile 将请求中的规则文件名限制在 rulesDir 之内，拒绝包含
+// 路径分隔符或 ".." 的文件名，防止通过该未鉴权接口读取任意文件。
+func resolveRulesFile(rulesDir, rulesFile string) (string, error) {
+	if rulesFile != filepath.Base(rulesFile) {
+		return "", fmt.Errorf("rules_file must not contain path separators: %q", rulesFile)
+	}
+	path := filepath.Join(rulesDir, rulesFile)
+	absRulesDir, err := filepath.Abs(rulesDir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(absRulesDir, absPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("rules_file escapes rules directory: %q", rulesFile)
+	}
+	return path, nil
+}
+
+// validateRequest 是 POST /rules/validate 的请求体：可选指定规则文件
+// （默认使用 RulesDir 下的 syslog.rules），以及一条用于试跑的样例消息。
+type validateRequest struct {
+	RulesFile string `json:"rules_file,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (s *adminServer) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req validateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	rulesFile := req.RulesFile
+	if rulesFile == "" {
+		rulesFile = "syslog.rules"
+	}
+	rulesPath, err := resolveRulesFile(s.rulesDir, rulesFile)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid rules_file: %v", err), http.StatusBadRequest)
+		return
+	}
+	rule, err := loadRules(rulesPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid rules file: %v", err), http.StatusBadRequest)
+		return
+	}
+	compileRuleLogic(&rule)
+
+	message, level := parseSyslogMessage(req.Message, rule.Logic)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": message, "level": level})
+}
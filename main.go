@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
 	"flag"
@@ -9,10 +11,14 @@ import (
 	"log"
 	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql" // 导入 MySQL 驱动程序
@@ -21,11 +27,23 @@ import (
 
 // 定义配置结构
 type Config struct {
-	Port     int    `yaml:"port"`
-	SendAddr string `yaml:"sendaddr"`
-	SendPort int    `yaml:"sendport"`
-	RulesDir string `yaml:"rulesdir"`
-	Mod      string `yaml:"mod"`
+	Port           int    `yaml:"port"`
+	SendAddr       string `yaml:"sendaddr"`
+	SendPort       int    `yaml:"sendport"`
+	RulesDir       string `yaml:"rulesdir"`
+	Mod            string `yaml:"mod"`
+	Framing        string `yaml:"framing"`        // TCP 组帧模式："newline"（默认）或 "octet"（RFC 6587）
+	MaxMessageSize int    `yaml:"maxmessagesize"` // TCP/TLS 单条消息的最大字节数，<=0 时使用 DefaultMaxMessageSize；UDP 不使用此项，每个数据报最多读取 2048 字节
+
+	Sinks []SinkConfig `yaml:"sinks"` // 输出目的地列表，未配置时退化为本地文件 + MySQL
+
+	TLSCertFile     string   `yaml:"tlscertfile"`     // mod 为 tls/tls+octet 时必填
+	TLSKeyFile      string   `yaml:"tlskeyfile"`      // mod 为 tls/tls+octet 时必填
+	TLSClientCAFile string   `yaml:"tlsclientcafile"` // 非空时启用双向认证（mTLS）
+	TLSMinVersion   string   `yaml:"tlsminversion"`   // "1.0"~"1.3"，默认 "1.2"
+	TLSCipherSuites []string `yaml:"tlsciphersuites"` // 可选，按名称指定允许的密码套件
+
+	AdminAddr string `yaml:"adminaddr,omitempty"` // HTTP 管理端口，例如 ":8080"；留空则不启动管理服务
 }
 
 // 定义数据库配置结构
@@ -36,6 +54,10 @@ type DBConfig struct {
 	MyPasswd   string `yaml:"mypasswd"`
 	MyDatabase string `yaml:"mydatabase"`
 	Enable     string `yaml:"enable"`
+
+	MaxOpenConns       int `yaml:"maxopenconns"`       // 最大打开连接数，默认 20
+	MaxIdleConns       int `yaml:"maxidleconns"`       // 最大空闲连接数，默认 10
+	ConnMaxLifetimeSec int `yaml:"connmaxlifetimesec"` // 连接最长存活时间（秒），默认 300
 }
 
 // 定义规则结构
@@ -48,10 +70,11 @@ type Logic struct {
 	Message   string `json:"message"`
 	Level     string `json:"level"`
 	Include   string `json:"include,omitempty"`
+
+	compiled Expr // 加载规则时编译好的条件表达式，"default" 规则不编译
 }
 
 var db *sql.DB
-var mutex sync.Mutex
 
 // 初始化数据库连接
 func init() {
@@ -81,79 +104,137 @@ func init() {
 		if err := db.Ping(); err != nil {
 			log.Fatalf("Failed to ping MySQL: %v", err)
 		}
+
+		maxOpenConns := dbConfig.MaxOpenConns
+		if maxOpenConns <= 0 {
+			maxOpenConns = 20
+		}
+		maxIdleConns := dbConfig.MaxIdleConns
+		if maxIdleConns <= 0 {
+			maxIdleConns = 10
+		}
+		connMaxLifetimeSec := dbConfig.ConnMaxLifetimeSec
+		if connMaxLifetimeSec <= 0 {
+			connMaxLifetimeSec = 300
+		}
+		db.SetMaxOpenConns(maxOpenConns)
+		db.SetMaxIdleConns(maxIdleConns)
+		db.SetConnMaxLifetime(time.Duration(connMaxLifetimeSec) * time.Second)
+
 		log.Println("Successfully connected to MySQL")
 	} else {
 		log.Println("Database write is disabled.")
 	}
 }
 
-// 用于存储日志的函数
-func storeLocalLog(message string, level string) {
-	currentTime := time.Now().Format("2006-01-02 15:04:05") // 获取当前时间并格式化
-	file, err := os.OpenFile("syslog.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatalf("Failed to open syslog.log: %v", err)
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(fmt.Sprintf("%s - %s - %s: %s\n", currentTime, level, "syslog", message))
+// 加载规则文件，支持 include（包括 glob 模式，如 "rules.d/*.json"）
+func loadRules(rulesFilePath string) (Rule, error) {
+	absPath, err := filepath.Abs(rulesFilePath)
 	if err != nil {
-		log.Printf("Failed to write to syslog.log: %v", err)
-	}
-}
-
-// 用于转发远程日志的函数
-func sendRemoteLog(message string, level string, addr string, port int) {
-	if addr == "" {
-		return
+		return Rule{}, fmt.Errorf("failed to resolve absolute path for %s: %v", rulesFilePath, err)
 	}
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", addr, port))
+	rule, err := readRuleFile(rulesFilePath)
 	if err != nil {
-		log.Printf("Failed to connect to remote syslog server: %v", err)
-		return
+		return Rule{}, err
 	}
-	defer conn.Close()
 
-	_, err = conn.Write([]byte(fmt.Sprintf("%s - %s: %s\n", level, "syslog", message)))
+	resolved, err := resolveIncludes(rule.Logic, filepath.Dir(rulesFilePath), map[string]bool{absPath: true}, []string{absPath})
 	if err != nil {
-		log.Printf("Failed to send to remote syslog server: %v", err)
+		return Rule{}, err
 	}
+	rule.Logic = resolved
+	return rule, nil
 }
 
-// 加载规则文件，支持 include
-func loadRules(rulesFilePath string) (Rule, error) {
-	data, err := ioutil.ReadFile(rulesFilePath)
+// readRuleFile 读取并解析单个规则文件，不处理其中的 include。
+func readRuleFile(path string) (Rule, error) {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return Rule{}, fmt.Errorf("failed to read rules file: %v", err)
 	}
 
 	var rule Rule
-	err = json.Unmarshal(data, &rule)
-	if err != nil {
+	if err := json.Unmarshal(data, &rule); err != nil {
 		return Rule{}, fmt.Errorf("failed to parse rules file: %v", err)
 	}
+	return rule, nil
+}
+
+// resolveIncludes 展开 logic 中的 include 条目（支持 glob），避免原实现
+// "边遍历边向同一个切片追加" 的 bug：只遍历传入的 logic，结果写入一个新的
+// 切片，被包含的文件通过递归调用展开（不使用显式的工作列表，递归深度等于
+// 包含链的长度）。visited 记录当前包含链上已访问过的绝对路径，
+// 用于检测循环引用；chain 仅用于在报错时还原完整的包含路径。
+func resolveIncludes(logic []Logic, baseDir string, visited map[string]bool, chain []string) ([]Logic, error) {
+	var result []Logic
+
+	for _, entry := range logic {
+		if entry.Include == "" {
+			result = append(result, entry)
+			continue
+		}
+
+		pattern := filepath.Join(baseDir, entry.Include)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %v", entry.Include, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("include %q matched no files (pattern %s)", entry.Include, pattern)
+		}
+		sort.Strings(matches)
+
+		for _, includePath := range matches {
+			absIncludePath, err := filepath.Abs(includePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve absolute path for %s: %v", includePath, err)
+			}
+			if visited[absIncludePath] {
+				return nil, fmt.Errorf("cycle detected in rule includes: %s -> %s", strings.Join(chain, " -> "), absIncludePath)
+			}
 
-	// 处理 include 语句
-	for _, logic := range rule.Logic {
-		if logic.Include != "" {
-			// 构造被包含文件的路径
-			rulesDir := filepath.Dir(rulesFilePath)
-			includePath := filepath.Join(rulesDir, logic.Include)
-			// 递归加载被包含的规则文件
-			includeRule, err := loadRules(includePath)
+			includeRule, err := readRuleFile(includePath)
 			if err != nil {
-				return Rule{}, err
+				return nil, err
+			}
+
+			nextVisited := make(map[string]bool, len(visited)+1)
+			for k, v := range visited {
+				nextVisited[k] = v
 			}
-			rule.Logic = append(rule.Logic, includeRule.Logic...)
+			nextVisited[absIncludePath] = true
+
+			nested, err := resolveIncludes(includeRule.Logic, filepath.Dir(includePath), nextVisited, append(chain, absIncludePath))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, nested...)
 		}
 	}
 
-	return rule, nil
+	return result, nil
+}
+
+// compileRuleLogic 在规则加载完成后一次性编译所有条件表达式，
+// 避免每条消息到来时都重新解析条件字符串。
+func compileRuleLogic(rule *Rule) {
+	for i := range rule.Logic {
+		logic := &rule.Logic[i]
+		if logic.Include != "" || logic.Condition == "default" {
+			continue
+		}
+		compiled, err := CompileExpr(logic.Condition)
+		if err != nil {
+			log.Printf("Failed to compile rule condition %q: %v", logic.Condition, err)
+			continue
+		}
+		logic.compiled = compiled
+	}
 }
 
 // 解析逻辑规则
-func evalLogic(logic []Logic, parts []string) (string, string) {
+func evalLogic(logic []Logic, vars map[string]string) (string, string) {
 	for _, rule := range logic {
 		// 跳过包含 include 的规则
 		if rule.Include != "" {
@@ -161,28 +242,40 @@ func evalLogic(logic []Logic, parts []string) (string, string) {
 		}
 
 		var condition bool
-		if rule.Condition == "default" {
+		switch {
+		case rule.Condition == "default":
 			condition = true
-		} else {
-			conditionStr := replaceVariables(rule.Condition, parts)
+		case rule.compiled != nil:
+			condition = truthy(rule.compiled.Eval(vars))
+		default:
+			// 规则未能在加载时编译（例如规则文件是热重载前的旧格式），
+			// 退化为旧的单一 left == right 通配符比较
+			conditionStr := replaceVariables(rule.Condition, vars)
 			condition = evalCondition(conditionStr)
 		}
 
 		if condition {
-			message := replaceVariables(rule.Message, parts)
-			level := replaceVariables(rule.Level, parts)
+			message := replaceVariables(rule.Message, vars)
+			level := replaceVariables(rule.Level, vars)
 			return message, level
 		}
 	}
 
 	// 默认值
-	return strings.Join(parts, " "), "info"
+	return vars["msg"], "info"
 }
 
-// 替换变量
-func replaceVariables(template string, match []string) string {
-	for i, val := range match {
-		template = strings.ReplaceAll(template, fmt.Sprintf("$%d", i), val)
+// 替换变量，例如 $hostname、$severity、$sd.origin.ip
+func replaceVariables(template string, vars map[string]string) string {
+	// 先替换较长的变量名，避免 $host 抢先匹配 $hostname 的前缀
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		template = strings.ReplaceAll(template, "$"+name, vars[name])
 	}
 	return template
 }
@@ -195,22 +288,50 @@ func wildcardToRegex(pattern string) string {
 	return "^" + pattern + "$"
 }
 
-// 左右字符串对比,rithstr为模糊匹配字段
-func regex_strings(lestr string, rithstr string) bool {
+var wildcardRegexCache sync.Map // 通配符模式 -> 已编译的 *regexp.Regexp
+var plainRegexCache sync.Map    // regex() 函数使用的原始正则模式 -> 已编译的 *regexp.Regexp
 
-	// 将通配符表达式转换为正则表达式
-	regexPattern := wildcardToRegex(rithstr)
+// compileWildcard 编译通配符表达式对应的正则，并缓存结果，
+// 避免每条消息都重新编译相同的模式。
+func compileWildcard(pattern string) (*regexp.Regexp, error) {
+	if v, ok := wildcardRegexCache.Load(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(wildcardToRegex(pattern))
+	if err != nil {
+		return nil, err
+	}
+	wildcardRegexCache.Store(pattern, re)
+	return re, nil
+}
+
+// compileRegex 编译原始（非通配符）正则表达式并缓存结果，供 regex() 规则函数使用。
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if v, ok := plainRegexCache.Load(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	plainRegexCache.Store(pattern, re)
+	return re, nil
+}
+
+// matchWildcard 是 regex_strings 的别名，供表达式引擎的 == / != 比较使用
+func matchWildcard(lestr string, rithstr string) bool {
+	return regex_strings(lestr, rithstr)
+}
 
-	// 编译并匹配
-	re, err := regexp.Compile(regexPattern)
+// 左右字符串对比,rithstr为模糊匹配字段
+func regex_strings(lestr string, rithstr string) bool {
+	re, err := compileWildcard(rithstr)
 	if err != nil {
 		fmt.Println("正则表达式编译错误:", err)
 		return false
 	}
 
-	isMatch := re.MatchString(lestr)
-	fmt.Printf("字符串 '%s' 是否匹配通配符表达式 '%s': %v\n", lestr, rithstr, isMatch)
-	return isMatch
+	return re.MatchString(lestr)
 }
 
 // 评估条件
@@ -224,47 +345,38 @@ func evalCondition(conditionStr string) bool {
 	return regex_strings(left, right)
 }
 
-// 使用规则解析 syslog 消息
+// 使用规则解析 syslog 消息。优先按 RFC 3164/5424 结构化解析，
+// 解析失败时回退为按空白切分的位置变量（$0、$1、...），以兼容非标准格式的输入。
 func parseSyslogMessage(message string, logic []Logic) (string, string) {
-	parts := strings.Fields(message)
-	newMessage, level := evalLogic(logic, parts)
-	return newMessage, level
-}
-
-// 将日志写入 MySQL 数据库
-func storeToMySQL(message string, level string) {
-	// 获取当前时间并格式化
-	currentTime := time.Now().Format("2006-01-02 15:04:05")
-	if db == nil {
-		log.Println("Database write is disabled.")
-		return
+	var vars map[string]string
+	if parsed, err := ParseSyslogMessage(message); err == nil {
+		vars = parsed.ToVars()
+	} else {
+		parseErrorsTotal.Inc()
+		parts := strings.Fields(message)
+		vars = make(map[string]string, len(parts)+1)
+		for i, p := range parts {
+			vars[strconv.Itoa(i)] = p
+		}
+		vars["msg"] = message
 	}
 
-	// 使用互斥锁确保数据库操作的线程安全
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	_, err := db.Exec("INSERT INTO syslog (currentTime, level,message) VALUES (?, ?, ?)", currentTime, level, message)
-	if err != nil {
-		log.Printf("Failed to insert data into MySQL: %v", err)
-	}
+	newMessage, level := evalLogic(logic, vars)
+	return newMessage, level
 }
 
-// 处理单个 syslog 消息
-func handleSyslogMessage(message string, rule Rule) {
+// 处理单个 syslog 消息，解析后扇出给所有已配置的 Sink
+func handleSyslogMessage(message string, rule *Rule, sinks []Sink) {
+	messagesReceivedTotal.Inc()
 	log.Printf("Received message: %s", message)
 
 	parsedMessage, level := parseSyslogMessage(message, rule.Logic)
 	log.Printf("Parsed message: %s, Level: %s", parsedMessage, level)
 
-	// 存储到本地日志文件
-	storeLocalLog(parsedMessage, level)
-
-	// 存储到 MySQL 数据库（如果启用了）
-	storeToMySQL(parsedMessage, level)
-
-	// 如果有远程转发地址，则进行远程转发
-	// 这里省略了远程转发的代码，可以根据需要添加
+	entry := LogEntry{Message: parsedMessage, Level: level}
+	for _, sink := range sinks {
+		sink.Write(entry)
+	}
 }
 
 func main() {
@@ -283,14 +395,48 @@ func main() {
 		log.Fatalf("Failed to parse config file: %v", err)
 	}
 
-	// 加载规则文件
+	log.SetOutput(os.Stdout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	// 加载规则文件，并在 RulesDir 发生变化时自动热重载
 	rulesFilePath := filepath.Join(config.RulesDir, "syslog.rules")
-	rule, err := loadRules(rulesFilePath)
+	rm, err := NewRuleManager(rulesFilePath)
 	if err != nil {
 		log.Fatalf("Failed to load rules: %v", err)
 	}
+	if err := rm.Watch(ctx, config.RulesDir); err != nil {
+		log.Printf("Failed to watch rules directory %s for changes: %v", config.RulesDir, err)
+	}
 
-	log.SetOutput(os.Stdout)
+	// 未配置 sinks 时，退化为旧版本的本地文件 + MySQL 行为
+	if len(config.Sinks) == 0 {
+		config.Sinks = []SinkConfig{{Type: "file", Path: "syslog.log"}, {Type: "mysql"}}
+	}
+	sinks := buildSinks(config.Sinks)
+
+	framing := config.Framing
+	if framing == "" {
+		framing = FramingNewline
+	}
+
+	maxMessageSize := config.MaxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultMaxMessageSize
+	}
+
+	if config.AdminAddr != "" {
+		startAdminServer(ctx, config.AdminAddr, rm, config.RulesDir)
+	}
+
+	var wg sync.WaitGroup
 
 	switch config.Mod {
 	case "tcp":
@@ -299,35 +445,30 @@ func main() {
 		if err != nil {
 			log.Fatalf("Failed to listen on TCP port %d: %v", config.Port, err)
 		}
-		defer listener.Close()
 
 		log.Printf("Syslog server started on TCP port %d", config.Port)
+		runTCPListener(ctx, listener, framing, maxMessageSize, rm, sinks, &wg)
 
-		var wg sync.WaitGroup
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				log.Printf("Failed to accept TCP connection: %v", err)
-				continue
-			}
-
-			wg.Add(1)
-			go func(conn net.Conn) {
-				defer wg.Done()
-				defer conn.Close()
+	case "tls", "tls+octet":
+		tlsConfig, err := buildTLSConfig(config)
+		if err != nil {
+			log.Fatalf("Failed to build TLS config: %v", err)
+		}
 
-				buffer := make([]byte, 2048)
-				n, err := conn.Read(buffer)
-				if err != nil {
-					log.Printf("Failed to read TCP message: %v", err)
-					return
-				}
+		addr := fmt.Sprintf(":%d", config.Port)
+		listener, err := tls.Listen("tcp", addr, tlsConfig)
+		if err != nil {
+			log.Fatalf("Failed to listen on TLS port %d: %v", config.Port, err)
+		}
 
-				message := strings.TrimSpace(string(buffer[:n]))
-				handleSyslogMessage(message, rule)
-			}(conn)
+		tlsFraming := FramingNewline
+		if config.Mod == "tls+octet" {
+			tlsFraming = FramingOctet
 		}
 
+		log.Printf("Syslog server started on TLS port %d (mod=%s)", config.Port, config.Mod)
+		runTCPListener(ctx, listener, tlsFraming, maxMessageSize, rm, sinks, &wg)
+
 	case "udp":
 		addr := fmt.Sprintf(":%d", config.Port)
 		udpAddr, err := net.ResolveUDPAddr("udp", addr)
@@ -339,29 +480,111 @@ func main() {
 		if err != nil {
 			log.Fatalf("Failed to listen on UDP port %d: %v", config.Port, err)
 		}
-		defer conn.Close()
+
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
 
 		log.Printf("Syslog server started on UDP port %d", config.Port)
 
-		var wg sync.WaitGroup
 		for {
 			buffer := make([]byte, 2048)
-			n, src, err := conn.ReadFromUDP(buffer)
+			n, _, err := conn.ReadFromUDP(buffer)
 			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
 				log.Printf("Failed to read UDP message: %v", err)
 				continue
 			}
 
 			wg.Add(1)
-			go func(src net.Addr, buffer []byte, n int) {
+			go func(buffer []byte, n int) {
 				defer wg.Done()
 
 				message := strings.TrimSpace(string(buffer[:n]))
-				handleSyslogMessage(message, rule)
-			}(src, buffer, n)
+				handleSyslogMessage(message, rm.Get(), sinks)
+			}(buffer, n)
 		}
 
 	default:
-		log.Fatalf("Unknown mod: %s. Use 'tcp' or 'udp'.", config.Mod)
+		log.Fatalf("Unknown mod: %s. Use 'tcp', 'udp', 'tls' or 'tls+octet'.", config.Mod)
+	}
+
+	waitForShutdown(&wg, sinks)
+}
+
+// runTCPListener 在任意流式 net.Listener（明文 TCP 或 TLS）上接受连接，
+// 每条连接交给 handleTCPConnection 持续处理，直到 ctx 被取消为止。
+func runTCPListener(ctx context.Context, listener net.Listener, framing string, maxMessageSize int, rm *RuleManager, sinks []Sink, wg *sync.WaitGroup) {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to accept connection: %v", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(conn net.Conn) {
+			defer wg.Done()
+			handleTCPConnection(ctx, conn, framing, maxMessageSize, rm, sinks)
+		}(conn)
+	}
+}
+
+// handleTCPConnection 持续从一条 TCP 连接中读取多条消息，直到对端关闭连接
+// 或服务进程收到关闭信号为止，而不是像过去那样只读一次就断开。
+func handleTCPConnection(ctx context.Context, conn net.Conn, framing string, maxMessageSize int, rm *RuleManager, sinks []Sink) {
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	scanner := newFrameScanner(conn, framing, maxMessageSize)
+	for scanner.Scan() {
+		if message := scanner.Text(); message != "" {
+			handleSyslogMessage(message, rm.Get(), sinks)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Failed to read TCP message: %v", err)
+	}
+}
+
+// waitForShutdown 等待所有在途连接处理完毕（超时则强制退出），然后
+// 刷新并关闭所有 Sink，确保已缓冲的消息在进程退出前落地。
+func waitForShutdown(wg *sync.WaitGroup, sinks []Sink) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("All in-flight connections finished")
+	case <-time.After(10 * time.Second):
+		log.Println("Timed out waiting for in-flight connections, forcing shutdown")
+	}
+
+	for _, sink := range sinks {
+		sink.Close()
 	}
+	log.Println("Sinks flushed, exiting")
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRuleFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadRulesResolvesGlobInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, filepath.Join(dir, "base.json"), `{
+		"logic": [
+			{"include": "rules.d/*.json"},
+			{"condition": "default", "message": "$msg", "level": "info"}
+		]
+	}`)
+	writeRuleFile(t, filepath.Join(dir, "rules.d", "a.json"), `{
+		"logic": [{"condition": "$severity == \"err\"", "message": "err: $msg", "level": "error"}]
+	}`)
+	writeRuleFile(t, filepath.Join(dir, "rules.d", "b.json"), `{
+		"logic": [{"condition": "$severity == \"warn\"", "message": "warn: $msg", "level": "warning"}]
+	}`)
+
+	rule, err := loadRules(filepath.Join(dir, "base.json"))
+	if err != nil {
+		t.Fatalf("loadRules returned error: %v", err)
+	}
+	if len(rule.Logic) != 3 {
+		t.Fatalf("expected 3 logic entries after glob expansion, got %d", len(rule.Logic))
+	}
+	for _, l := range rule.Logic {
+		if l.Include != "" {
+			t.Errorf("expected includes to be fully resolved, found unresolved include %q", l.Include)
+		}
+	}
+}
+
+func TestLoadRulesDetectsIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, filepath.Join(dir, "a.json"), `{"logic": [{"include": "b.json"}]}`)
+	writeRuleFile(t, filepath.Join(dir, "b.json"), `{"logic": [{"include": "a.json"}]}`)
+
+	_, err := loadRules(filepath.Join(dir, "a.json"))
+	if err == nil {
+		t.Fatalf("expected cycle detection error, got nil")
+	}
+}
+
+func TestLoadRulesMissingIncludeMatch(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, filepath.Join(dir, "base.json"), `{"logic": [{"include": "nothere/*.json"}]}`)
+
+	_, err := loadRules(filepath.Join(dir, "base.json"))
+	if err == nil {
+		t.Fatalf("expected error for include pattern matching no files, got nil")
+	}
+}
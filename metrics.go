@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus 指标：消息速率、解析错误、各 Sink 错误与队列深度
+var (
+	messagesReceivedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "syslog_messages_received_total",
+		Help: "Total number of syslog messages received.",
+	})
+	parseErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "syslog_parse_errors_total",
+		Help: "Total number of messages that failed RFC 3164/5424 parsing and fell back to positional fields.",
+	})
+	sinkErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "syslog_sink_errors_total",
+		Help: "Total number of write errors per output sink.",
+	}, []string{"sink"})
+	sinkQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "syslog_sink_queue_depth",
+		Help: "Current number of buffered messages waiting to be written per sink.",
+	}, []string{"sink"})
+)
+
+func init() {
+	prometheus.MustRegister(messagesReceivedTotal, parseErrorsTotal, sinkErrorsTotal, sinkQueueDepth)
+
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "syslog_mysql_queue_depth",
+		Help: "Current number of buffered rows waiting to be batch-inserted into MySQL.",
+	}, func() float64 { return float64(atomic.LoadInt64(&mysqlQueueDepth)) }))
+
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "syslog_mysql_insert_latency_ms",
+		Help: "Duration of the most recent MySQL batch insert, in milliseconds.",
+	}, func() float64 { return float64(atomic.LoadInt64(&mysqlInsertLatencyMs)) }))
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Expr 是编译后的规则条件表达式节点，在加载规则时构建一次，
+// 之后每条消息只需求值，避免重复解析/编译。
+type Expr interface {
+	Eval(vars map[string]string) interface{}
+}
+
+// truthy 把表达式求值结果转换为布尔条件
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case float64:
+		return t != 0
+	default:
+		return false
+	}
+}
+
+// ---- 词法分析 ----
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokVar
+	tokString
+	tokNumber
+	tokIdent
+	tokAnd
+	tokOr
+	tokNot
+	tokEq
+	tokNeq
+	tokGt
+	tokLt
+	tokGe
+	tokLe
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	i, n := 0, len(expr)
+
+	for i < n {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ","})
+			i++
+		case c == '&' && i+1 < n && expr[i+1] == '&':
+			tokens = append(tokens, token{tokAnd, "&&"})
+			i += 2
+		case c == '|' && i+1 < n && expr[i+1] == '|':
+			tokens = append(tokens, token{tokOr, "||"})
+			i += 2
+		case c == '=' && i+1 < n && expr[i+1] == '=':
+			tokens = append(tokens, token{tokEq, "=="})
+			i += 2
+		case c == '!' && i+1 < n && expr[i+1] == '=':
+			tokens = append(tokens, token{tokNeq, "!="})
+			i += 2
+		case c == '!':
+			tokens = append(tokens, token{tokNot, "!"})
+			i++
+		case c == '>' && i+1 < n && expr[i+1] == '=':
+			tokens = append(tokens, token{tokGe, ">="})
+			i += 2
+		case c == '>':
+			tokens = append(tokens, token{tokGt, ">"})
+			i++
+		case c == '<' && i+1 < n && expr[i+1] == '=':
+			tokens = append(tokens, token{tokLe, "<="})
+			i += 2
+		case c == '<':
+			tokens = append(tokens, token{tokLt, "<"})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < n && expr[j] != '"' {
+				j++
+			}
+			if j >= n {
+				return nil, fmt.Errorf("unterminated string literal in condition: %q", expr)
+			}
+			tokens = append(tokens, token{tokString, expr[i+1 : j]})
+			i = j + 1
+		case c == '$':
+			j := i + 1
+			for j < n && isVarChar(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokVar, expr[i+1 : j]})
+			i = j
+		case isDigit(c) || (c == '-' && i+1 < n && isDigit(expr[i+1])):
+			j := i + 1
+			for j < n && (isDigit(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, expr[i:j]})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < n && isIdentChar(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, expr[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q in condition: %q", string(c), expr)
+		}
+	}
+
+	tokens = append(tokens, token{tokEOF, ""})
+	return tokens, nil
+}
+
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' }
+func isIdentChar(c byte) bool  { return isIdentStart(c) || isDigit(c) }
+
+// isVarChar 允许变量名中出现 '.'（字段分隔符）和 '@'，后者用于
+// RFC 5424 结构化数据中带企业号的 SD-ID（如 exampleSDID@32473）。
+func isVarChar(c byte) bool { return isIdentChar(c) || c == '.' || c == '@' }
+
+// ---- 语法分析（递归下降，按优先级分层：|| < && < ! < 比较 < 原子） ----
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+// CompileExpr 将规则条件字符串编译为可重复求值的表达式树。
+func CompileExpr(condition string) (Expr, error) {
+	tokens, err := tokenize(condition)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected trailing token %q in condition: %q", p.peek().text, condition)
+	}
+	return expr, nil
+}
+
+func (p *exprParser) peek() token { return p.tokens[p.pos] }
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: tokOr, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: tokAnd, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (Expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.peek().kind {
+	case tokEq, tokNeq, tokGt, tokLt, tokGe, tokLe:
+		op := p.next().kind
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokLParen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis in condition")
+		}
+		p.next()
+		return expr, nil
+	case tokVar:
+		p.next()
+		return &varNode{name: t.text}, nil
+	case tokString:
+		p.next()
+		return &litNode{value: t.text}, nil
+	case tokNumber:
+		p.next()
+		num, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number literal %q", t.text)
+		}
+		return &litNode{value: t.text, num: num, isNum: true}, nil
+	case tokIdent:
+		p.next()
+		if t.text == "true" {
+			return &litNode{boolVal: true, isBool: true}, nil
+		}
+		if t.text == "false" {
+			return &litNode{boolVal: false, isBool: true}, nil
+		}
+		if p.peek().kind != tokLParen {
+			return nil, fmt.Errorf("unexpected identifier %q in condition", t.text)
+		}
+		p.next()
+		var args []Expr
+		for p.peek().kind != tokRParen {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind == tokComma {
+				p.next()
+				continue
+			}
+			break
+		}
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis in call to %q", t.text)
+		}
+		p.next()
+		return &funcCallNode{name: t.text, args: args}, nil
+	}
+	return nil, fmt.Errorf("unexpected token %q in condition", t.text)
+}
+
+// ---- AST 节点 ----
+
+type litNode struct {
+	value   string
+	num     float64
+	isNum   bool
+	boolVal bool
+	isBool  bool
+}
+
+func (n *litNode) Eval(vars map[string]string) interface{} {
+	switch {
+	case n.isBool:
+		return n.boolVal
+	case n.isNum:
+		return n.num
+	default:
+		return n.value
+	}
+}
+
+type varNode struct{ name string }
+
+func (n *varNode) Eval(vars map[string]string) interface{} { return vars[n.name] }
+
+type notNode struct{ operand Expr }
+
+func (n *notNode) Eval(vars map[string]string) interface{} {
+	return !truthy(n.operand.Eval(vars))
+}
+
+type logicalNode struct {
+	op          tokenKind
+	left, right Expr
+}
+
+func (n *logicalNode) Eval(vars map[string]string) interface{} {
+	left := truthy(n.left.Eval(vars))
+	if n.op == tokAnd {
+		return left && truthy(n.right.Eval(vars))
+	}
+	return left || truthy(n.right.Eval(vars))
+}
+
+type compareNode struct {
+	op          tokenKind
+	left, right Expr
+}
+
+func (n *compareNode) Eval(vars map[string]string) interface{} {
+	left := toStringValue(n.left.Eval(vars))
+	right := toStringValue(n.right.Eval(vars))
+
+	switch n.op {
+	case tokEq:
+		return matchWildcard(left, right)
+	case tokNeq:
+		return !matchWildcard(left, right)
+	}
+
+	leftNum, leftErr := strconv.ParseFloat(left, 64)
+	rightNum, rightErr := strconv.ParseFloat(right, 64)
+	if leftErr == nil && rightErr == nil {
+		switch n.op {
+		case tokGt:
+			return leftNum > rightNum
+		case tokLt:
+			return leftNum < rightNum
+		case tokGe:
+			return leftNum >= rightNum
+		case tokLe:
+			return leftNum <= rightNum
+		}
+	}
+
+	switch n.op {
+	case tokGt:
+		return left > right
+	case tokLt:
+		return left < right
+	case tokGe:
+		return left >= right
+	case tokLe:
+		return left <= right
+	}
+	return false
+}
+
+type funcCallNode struct {
+	name string
+	args []Expr
+}
+
+func (n *funcCallNode) Eval(vars map[string]string) interface{} {
+	argv := make([]string, len(n.args))
+	for i, a := range n.args {
+		argv[i] = toStringValue(a.Eval(vars))
+	}
+
+	switch n.name {
+	case "contains":
+		if len(argv) != 2 {
+			return false
+		}
+		return strings.Contains(argv[0], argv[1])
+	case "startsWith":
+		if len(argv) != 2 {
+			return false
+		}
+		return strings.HasPrefix(argv[0], argv[1])
+	case "regex":
+		if len(argv) != 2 {
+			return false
+		}
+		re, err := compileRegex(argv[1])
+		if err != nil {
+			return false
+		}
+		return re.MatchString(argv[0])
+	case "cidr":
+		if len(argv) != 2 {
+			return false
+		}
+		ip := net.ParseIP(argv[0])
+		_, network, err := net.ParseCIDR(argv[1])
+		if ip == nil || err != nil {
+			return false
+		}
+		return network.Contains(ip)
+	case "in":
+		if len(argv) < 2 {
+			return false
+		}
+		for _, candidate := range argv[1:] {
+			if matchWildcard(argv[0], candidate) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func toStringValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		if t {
+			return "true"
+		}
+		return "false"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import "testing"
+
+func evalCompiled(t *testing.T, condition string, vars map[string]string) interface{} {
+	t.Helper()
+	expr, err := CompileExpr(condition)
+	if err != nil {
+		t.Fatalf("CompileExpr(%q) returned error: %v", condition, err)
+	}
+	return expr.Eval(vars)
+}
+
+func TestCompileExprPrecedenceAndParens(t *testing.T) {
+	vars := map[string]string{"severity": "err", "hostname": "web-1", "msg": "access denied"}
+
+	cases := []struct {
+		condition string
+		want      bool
+	}{
+		{`$severity == "err" && $hostname == "web-*" || contains($msg, "denied")`, true},
+		{`$severity == "info" && $hostname == "web-*"`, false},
+		{`$severity == "info" && $hostname == "web-*" || contains($msg, "denied")`, true},
+		{`!($severity == "info")`, true},
+		{`($severity == "err" || $severity == "warn") && $hostname == "web-*"`, true},
+	}
+
+	for _, c := range cases {
+		got := truthy(evalCompiled(t, c.condition, vars))
+		if got != c.want {
+			t.Errorf("eval(%q) = %v, want %v", c.condition, got, c.want)
+		}
+	}
+}
+
+func TestCompileExprComparisonOperators(t *testing.T) {
+	vars := map[string]string{"count": "5"}
+
+	cases := []struct {
+		condition string
+		want      bool
+	}{
+		{`$count > 3`, true},
+		{`$count < 3`, false},
+		{`$count >= 5`, true},
+		{`$count <= 4`, false},
+		{`$count != "3"`, true},
+	}
+
+	for _, c := range cases {
+		got := truthy(evalCompiled(t, c.condition, vars))
+		if got != c.want {
+			t.Errorf("eval(%q) = %v, want %v", c.condition, got, c.want)
+		}
+	}
+}
+
+func TestCompileExprBuiltinFunctions(t *testing.T) {
+	vars := map[string]string{
+		"msg":  "authentication failure for user root",
+		"host": "10.0.0.5",
+		"app":  "sshd",
+	}
+
+	cases := []struct {
+		condition string
+		want      bool
+	}{
+		{`contains($msg, "failure")`, true},
+		{`contains($msg, "success")`, false},
+		{`startsWith($msg, "authentication")`, true},
+		{`regex($msg, "^authentication .* root$")`, true},
+		{`cidr($host, "10.0.0.0/24")`, true},
+		{`cidr($host, "192.168.0.0/24")`, false},
+		{`in($app, "sshd", "nginx", "cron")`, true},
+		{`in($app, "nginx", "cron")`, false},
+	}
+
+	for _, c := range cases {
+		got := truthy(evalCompiled(t, c.condition, vars))
+		if got != c.want {
+			t.Errorf("eval(%q) = %v, want %v", c.condition, got, c.want)
+		}
+	}
+}
+
+func TestCompileExprSDVariableWithEnterpriseNumber(t *testing.T) {
+	vars := map[string]string{"sd.exampleSDID@32473.iut": "3"}
+
+	got := truthy(evalCompiled(t, `$sd.exampleSDID@32473.iut == "3"`, vars))
+	if !got {
+		t.Errorf("expected SD-ID variable with enterprise number to resolve and match")
+	}
+}
+
+func TestCompileExprUnterminatedStringError(t *testing.T) {
+	if _, err := CompileExpr(`$msg == "unterminated`); err == nil {
+		t.Errorf("expected error for unterminated string literal, got nil")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// RuleManager 持有当前生效的规则集，支持通过 fsnotify 监听规则目录
+// 并在文件变化时原子地替换规则，调用方无需重启进程。
+type RuleManager struct {
+	path    string
+	current atomic.Value // 存放 *Rule
+}
+
+// NewRuleManager 加载并编译 path 指向的规则文件，构造一个 RuleManager。
+func NewRuleManager(path string) (*RuleManager, error) {
+	rule, err := loadAndCompileRules(path)
+	if err != nil {
+		return nil, err
+	}
+	rm := &RuleManager{path: path}
+	rm.current.Store(rule)
+	return rm, nil
+}
+
+func loadAndCompileRules(path string) (*Rule, error) {
+	rule, err := loadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	compileRuleLogic(&rule)
+	return &rule, nil
+}
+
+// Get 返回当前生效的规则集，可在任意 goroutine 中并发调用。
+func (rm *RuleManager) Get() *Rule {
+	return rm.current.Load().(*Rule)
+}
+
+// Reload 重新从磁盘加载规则文件并原子替换当前规则集。
+// 加载失败时保留旧规则集不变并返回错误，由调用方记录或上报。
+func (rm *RuleManager) Reload() error {
+	rule, err := loadAndCompileRules(rm.path)
+	if err != nil {
+		return err
+	}
+	rm.current.Store(rule)
+	log.Printf("Rules reloaded from %s", rm.path)
+	return nil
+}
+
+// Watch 监听规则目录下的文件变化，变化发生时自动调用 Reload。
+// 监听协程随 ctx 取消而退出。
+func (rm *RuleManager) Watch(ctx context.Context, rulesDir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(rulesDir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := rm.Reload(); err != nil {
+					log.Printf("Rejecting invalid rules update from %s: %v", event.Name, err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Rules watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	eslib "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentio/kafka-go"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogEntry 是送入各个 Sink 的一条已解析日志
+type LogEntry struct {
+	Message string
+	Level   string
+}
+
+// Sink 是日志输出目的地的统一接口，每个 Sink 内部维护自己的
+// 缓冲队列和工作协程，避免某个后端变慢时阻塞其它 Sink 或消息接收。
+type Sink interface {
+	Name() string
+	Write(entry LogEntry)
+	Close()
+}
+
+// SinkConfig 描述 config.yaml 中 sinks 列表的一项，字段按 Type 取用
+type SinkConfig struct {
+	Type       string   `yaml:"type"`
+	BufferSize int      `yaml:"buffersize,omitempty"`
+	Path       string   `yaml:"path,omitempty"`       // file
+	MaxSizeMB  int      `yaml:"maxsizemb,omitempty"`  // file
+	MaxBackups int      `yaml:"maxbackups,omitempty"` // file
+	MaxAgeDays int      `yaml:"maxagedays,omitempty"` // file
+	Compress   bool     `yaml:"compress,omitempty"`   // file
+	Brokers    []string `yaml:"brokers,omitempty"`     // kafka
+	Topic      string   `yaml:"topic,omitempty"`       // kafka
+	Addr       string   `yaml:"addr,omitempty"`        // redis, remote
+	Port       int      `yaml:"port,omitempty"`        // remote
+	Password   string   `yaml:"password,omitempty"`    // redis
+	DB         int      `yaml:"db,omitempty"`          // redis
+	Key        string   `yaml:"key,omitempty"`         // redis
+	Mode       string   `yaml:"mode,omitempty"`        // redis: "list"（默认）或 "stream"
+	URLs       []string `yaml:"urls,omitempty"`        // elasticsearch
+	Index      string   `yaml:"index,omitempty"`       // elasticsearch
+	BatchSize    int  `yaml:"batchsize,omitempty"`    // mysql：每批写入的行数，默认 100
+	BatchFlushMS int  `yaml:"batchflushms,omitempty"` // mysql：未凑满一批时的最长等待时间（毫秒），默认 1000
+	DropOldest   bool `yaml:"dropoldest,omitempty"`    // mysql：队列满时丢弃最旧的一行而不是丢弃新消息
+}
+
+type sinkFactory func(cfg SinkConfig) (Sink, error)
+
+var sinkFactories = map[string]sinkFactory{}
+
+func registerSink(typeName string, factory sinkFactory) {
+	sinkFactories[typeName] = factory
+}
+
+func init() {
+	registerSink("file", newFileSink)
+	registerSink("mysql", newMySQLSink)
+	registerSink("kafka", newKafkaSink)
+	registerSink("redis", newRedisSink)
+	registerSink("elasticsearch", newElasticsearchSink)
+	registerSink("remote", newRemoteSink)
+}
+
+// buildSinks 根据配置构造启用的 Sink 列表，未知类型或初始化失败的
+// Sink 只记录日志并跳过，不影响其它 Sink 正常工作。
+func buildSinks(configs []SinkConfig) []Sink {
+	var sinks []Sink
+	for _, cfg := range configs {
+		factory, ok := sinkFactories[cfg.Type]
+		if !ok {
+			log.Printf("Unknown sink type %q, skipping", cfg.Type)
+			continue
+		}
+		sink, err := factory(cfg)
+		if err != nil {
+			log.Printf("Failed to initialize sink %q: %v", cfg.Type, err)
+			continue
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+// baseSink 提供所有 Sink 共用的"缓冲 channel + 单个工作协程"骨架，
+// 这样某一个后端变慢只会堆积它自己的队列，不会拖慢消息接收或其它 Sink。
+type baseSink struct {
+	name string
+	ch   chan LogEntry
+	wg   sync.WaitGroup
+}
+
+func newBaseSink(name string, bufferSize int, handle func(LogEntry)) *baseSink {
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+	b := &baseSink{name: name, ch: make(chan LogEntry, bufferSize)}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for entry := range b.ch {
+			handle(entry)
+		}
+	}()
+	return b
+}
+
+func (b *baseSink) Name() string { return b.name }
+
+func (b *baseSink) Write(entry LogEntry) {
+	select {
+	case b.ch <- entry:
+	default:
+		log.Printf("Sink %s buffer full, dropping message", b.name)
+		sinkErrorsTotal.WithLabelValues(b.name).Inc()
+	}
+	sinkQueueDepth.WithLabelValues(b.name).Set(float64(len(b.ch)))
+}
+
+func (b *baseSink) Close() {
+	close(b.ch)
+	b.wg.Wait()
+}
+
+// ---- file sink：带滚动的本地文件写入 ----
+
+type fileSink struct {
+	*baseSink
+	logger *lumberjack.Logger
+}
+
+func newFileSink(cfg SinkConfig) (Sink, error) {
+	path := cfg.Path
+	if path == "" {
+		path = "syslog.log"
+	}
+	s := &fileSink{
+		logger: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		},
+	}
+	s.baseSink = newBaseSink("file", cfg.BufferSize, s.writeEntry)
+	return s, nil
+}
+
+func (s *fileSink) writeEntry(entry LogEntry) {
+	currentTime := time.Now().Format("2006-01-02 15:04:05")
+	line := fmt.Sprintf("%s - %s - %s: %s\n", currentTime, entry.Level, "syslog", entry.Message)
+	if _, err := s.logger.Write([]byte(line)); err != nil {
+		log.Printf("Failed to write to %s: %v", s.logger.Filename, err)
+		sinkErrorsTotal.WithLabelValues("file").Inc()
+	}
+}
+
+func (s *fileSink) Close() {
+	s.baseSink.Close()
+	s.logger.Close()
+}
+
+// ---- mysql sink：写入现有的 MySQL 连接 ----
+
+type mysqlRow struct {
+	currentTime string
+	level       string
+	message     string
+}
+
+// mysqlQueueDepth 和 mysqlInsertLatencyMs 暴露队列深度与最近一次批量写入耗时，
+// 供后续的 /metrics 端点读取。
+var mysqlQueueDepth int64
+var mysqlInsertLatencyMs int64
+
+// mysqlSink 把消息累积成批次后再写入 MySQL，避免逐条 INSERT 串行化吞吐。
+type mysqlSink struct {
+	ch            chan mysqlRow
+	wg            sync.WaitGroup
+	batchSize     int
+	flushInterval time.Duration
+	dropOldest    bool
+	batchStmt     *sql.Stmt // 预处理好的、恰好 batchSize 行的多值 INSERT
+}
+
+func newMySQLSink(cfg SinkConfig) (Sink, error) {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	flushMS := cfg.BatchFlushMS
+	if flushMS <= 0 {
+		flushMS = 1000
+	}
+	queueSize := cfg.BufferSize
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+
+	s := &mysqlSink{
+		ch:            make(chan mysqlRow, queueSize),
+		batchSize:     batchSize,
+		flushInterval: time.Duration(flushMS) * time.Millisecond,
+		dropOldest:    cfg.DropOldest,
+	}
+
+	if db != nil {
+		stmt, err := prepareBatchInsert(batchSize)
+		if err != nil {
+			log.Printf("Failed to prepare batched MySQL insert, falling back to dynamic queries: %v", err)
+		} else {
+			s.batchStmt = stmt
+		}
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+func prepareBatchInsert(batchSize int) (*sql.Stmt, error) {
+	placeholders := make([]string, batchSize)
+	for i := range placeholders {
+		placeholders[i] = "(?, ?, ?)"
+	}
+	query := fmt.Sprintf("INSERT INTO syslog (currentTime, level, message) VALUES %s", strings.Join(placeholders, ","))
+	return db.Prepare(query)
+}
+
+func (s *mysqlSink) Name() string { return "mysql" }
+
+func (s *mysqlSink) Write(entry LogEntry) {
+	row := mysqlRow{
+		currentTime: time.Now().Format("2006-01-02 15:04:05"),
+		level:       entry.Level,
+		message:     entry.Message,
+	}
+
+	if s.dropOldest {
+		select {
+		case s.ch <- row:
+		default:
+			select {
+			case <-s.ch:
+			default:
+			}
+			select {
+			case s.ch <- row:
+			default:
+			}
+		}
+	} else {
+		select {
+		case s.ch <- row:
+		default:
+			log.Printf("Sink mysql buffer full, dropping message")
+			sinkErrorsTotal.WithLabelValues("mysql").Inc()
+		}
+	}
+
+	atomic.StoreInt64(&mysqlQueueDepth, int64(len(s.ch)))
+	sinkQueueDepth.WithLabelValues("mysql").Set(float64(len(s.ch)))
+}
+
+func (s *mysqlSink) Close() {
+	close(s.ch)
+	s.wg.Wait()
+	if s.batchStmt != nil {
+		s.batchStmt.Close()
+	}
+}
+
+func (s *mysqlSink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]mysqlRow, 0, s.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.insertBatch(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case row, ok := <-s.ch:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, row)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (s *mysqlSink) insertBatch(rows []mysqlRow) {
+	if db == nil {
+		log.Println("Database write is disabled.")
+		return
+	}
+
+	start := time.Now()
+	defer func() { atomic.StoreInt64(&mysqlInsertLatencyMs, time.Since(start).Milliseconds()) }()
+
+	if s.batchStmt != nil && len(rows) == s.batchSize {
+		args := make([]interface{}, 0, len(rows)*3)
+		for _, r := range rows {
+			args = append(args, r.currentTime, r.level, r.message)
+		}
+		if _, err := s.batchStmt.Exec(args...); err != nil {
+			log.Printf("Failed to batch insert into MySQL: %v", err)
+			sinkErrorsTotal.WithLabelValues("mysql").Inc()
+		}
+		return
+	}
+
+	// 批次大小与预处理语句不一致（例如定时器触发的尾批），动态拼接多值 INSERT
+	placeholders := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*3)
+	for i, r := range rows {
+		placeholders[i] = "(?, ?, ?)"
+		args = append(args, r.currentTime, r.level, r.message)
+	}
+	query := fmt.Sprintf("INSERT INTO syslog (currentTime, level, message) VALUES %s", strings.Join(placeholders, ","))
+	if _, err := db.Exec(query, args...); err != nil {
+		log.Printf("Failed to insert batch into MySQL: %v", err)
+		sinkErrorsTotal.WithLabelValues("mysql").Inc()
+	}
+}
+
+// ---- kafka sink ----
+
+type kafkaSink struct {
+	*baseSink
+	writer *kafka.Writer
+}
+
+func newKafkaSink(cfg SinkConfig) (Sink, error) {
+	if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka sink requires brokers and topic")
+	}
+	s := &kafkaSink{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(cfg.Brokers...),
+			Topic:    cfg.Topic,
+			Balancer: &kafka.LeastBytes{},
+		},
+	}
+	s.baseSink = newBaseSink("kafka", cfg.BufferSize, s.writeEntry)
+	return s, nil
+}
+
+func (s *kafkaSink) writeEntry(entry LogEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to marshal message for kafka sink: %v", err)
+		return
+	}
+	if err := s.writer.WriteMessages(context.Background(), kafka.Message{Value: data}); err != nil {
+		log.Printf("Failed to write message to kafka: %v", err)
+		sinkErrorsTotal.WithLabelValues("kafka").Inc()
+	}
+}
+
+func (s *kafkaSink) Close() {
+	s.baseSink.Close()
+	s.writer.Close()
+}
+
+// ---- redis sink：写入 list（默认）或 stream ----
+
+type redisSink struct {
+	*baseSink
+	client *redis.Client
+	key    string
+	stream bool
+}
+
+func newRedisSink(cfg SinkConfig) (Sink, error) {
+	if cfg.Addr == "" || cfg.Key == "" {
+		return nil, fmt.Errorf("redis sink requires addr and key")
+	}
+	s := &redisSink{
+		client: redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB}),
+		key:    cfg.Key,
+		stream: cfg.Mode == "stream",
+	}
+	s.baseSink = newBaseSink("redis", cfg.BufferSize, s.writeEntry)
+	return s, nil
+}
+
+func (s *redisSink) writeEntry(entry LogEntry) {
+	ctx := context.Background()
+	if s.stream {
+		values := map[string]interface{}{"message": entry.Message, "level": entry.Level}
+		if err := s.client.XAdd(ctx, &redis.XAddArgs{Stream: s.key, Values: values}).Err(); err != nil {
+			log.Printf("Failed to write message to redis stream: %v", err)
+			sinkErrorsTotal.WithLabelValues("redis").Inc()
+		}
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to marshal message for redis sink: %v", err)
+		return
+	}
+	if err := s.client.RPush(ctx, s.key, data).Err(); err != nil {
+		log.Printf("Failed to write message to redis list: %v", err)
+		sinkErrorsTotal.WithLabelValues("redis").Inc()
+	}
+}
+
+func (s *redisSink) Close() {
+	s.baseSink.Close()
+	s.client.Close()
+}
+
+// ---- elasticsearch sink：使用 esutil.BulkIndexer 批量写入 ----
+
+type elasticsearchSink struct {
+	*baseSink
+	indexer esutil.BulkIndexer
+}
+
+func newElasticsearchSink(cfg SinkConfig) (Sink, error) {
+	if len(cfg.URLs) == 0 || cfg.Index == "" {
+		return nil, fmt.Errorf("elasticsearch sink requires urls and index")
+	}
+	client, err := eslib.NewClient(eslib.Config{Addresses: cfg.URLs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create elasticsearch client: %v", err)
+	}
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{Index: cfg.Index, Client: client})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create elasticsearch bulk indexer: %v", err)
+	}
+
+	s := &elasticsearchSink{indexer: indexer}
+	s.baseSink = newBaseSink("elasticsearch", cfg.BufferSize, s.writeEntry)
+	return s, nil
+}
+
+func (s *elasticsearchSink) writeEntry(entry LogEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Failed to marshal message for elasticsearch sink: %v", err)
+		return
+	}
+	err = s.indexer.Add(context.Background(), esutil.BulkIndexerItem{
+		Action: "index",
+		Body:   bytes.NewReader(data),
+		OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+			log.Printf("Failed to index message into elasticsearch: %v", err)
+			sinkErrorsTotal.WithLabelValues("elasticsearch").Inc()
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to queue message for elasticsearch sink: %v", err)
+		sinkErrorsTotal.WithLabelValues("elasticsearch").Inc()
+	}
+}
+
+func (s *elasticsearchSink) Close() {
+	s.baseSink.Close()
+	s.indexer.Close(context.Background())
+}
+
+// ---- remote sink：按 TCP 转发到另一个 syslog 接收端 ----
+
+type remoteSink struct {
+	*baseSink
+	addr string
+	port int
+}
+
+func newRemoteSink(cfg SinkConfig) (Sink, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("remote sink requires addr")
+	}
+	s := &remoteSink{addr: cfg.Addr, port: cfg.Port}
+	s.baseSink = newBaseSink("remote", cfg.BufferSize, s.writeEntry)
+	return s, nil
+}
+
+func (s *remoteSink) writeEntry(entry LogEntry) {
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", s.addr, s.port))
+	if err != nil {
+		log.Printf("Failed to connect to remote syslog server: %v", err)
+		sinkErrorsTotal.WithLabelValues("remote").Inc()
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(fmt.Sprintf("%s - %s: %s\n", entry.Level, "syslog", entry.Message)))
+	if err != nil {
+		log.Printf("Failed to send to remote syslog server: %v", err)
+		sinkErrorsTotal.WithLabelValues("remote").Inc()
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 组帧模式，用于 TCP 接收
+const (
+	FramingNewline = "newline" // 按换行符分隔（默认）
+	FramingOctet   = "octet"   // RFC 6587 八位字节计数组帧："<len> <msg>"
+)
+
+// SyslogMessage 表示一条解析后的 syslog 消息（RFC 3164 或 RFC 5424）
+type SyslogMessage struct {
+	Raw            string
+	Facility       int
+	Severity       int
+	Version        int // RFC 5424 版本号，RFC 3164 消息为 0
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string // sd-id -> (key -> value)
+	Msg            string
+}
+
+var rfc5424Re = regexp.MustCompile(`^<(\d{1,3})>(\d{1,2})\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(.*)$`)
+var rfc3164Re = regexp.MustCompile(`^<(\d{1,3})>([A-Za-z]{3}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2})\s+(\S+)\s+([^:\s\[]+)(?:\[(\d+)\])?:\s*(.*)$`)
+var sdElementRe = regexp.MustCompile(`\[([^\s\]]+)((?:\s+[^\s=]+="[^"]*")*)\]`)
+var sdParamRe = regexp.MustCompile(`([^\s=]+)="([^"]*)"`)
+
+// ParseSyslogMessage 解析一条原始 syslog 消息，优先尝试 RFC 5424，
+// 失败后回退到 RFC 3164。
+func ParseSyslogMessage(raw string) (*SyslogMessage, error) {
+	if m := rfc5424Re.FindStringSubmatch(raw); m != nil {
+		return parseRFC5424(raw, m)
+	}
+	if m := rfc3164Re.FindStringSubmatch(raw); m != nil {
+		return parseRFC3164(raw, m)
+	}
+	return nil, fmt.Errorf("unrecognized syslog format: %q", raw)
+}
+
+func parsePri(priStr string) (facility, severity int, err error) {
+	pri, err := strconv.Atoi(priStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid PRI %q: %v", priStr, err)
+	}
+	return pri / 8, pri % 8, nil
+}
+
+func parseRFC5424(raw string, m []string) (*SyslogMessage, error) {
+	facility, severity, err := parsePri(m[1])
+	if err != nil {
+		return nil, err
+	}
+	version, _ := strconv.Atoi(m[2])
+
+	msg := &SyslogMessage{
+		Raw:      raw,
+		Facility: facility,
+		Severity: severity,
+		Version:  version,
+		Hostname: nilDash(m[4]),
+		AppName:  nilDash(m[5]),
+		ProcID:   nilDash(m[6]),
+		MsgID:    nilDash(m[7]),
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, m[3]); err == nil {
+		msg.Timestamp = ts
+	}
+
+	rest := m[8]
+	sd, remainder := parseStructuredData(rest)
+	msg.StructuredData = sd
+	msg.Msg = strings.TrimSpace(remainder)
+
+	return msg, nil
+}
+
+func parseRFC3164(raw string, m []string) (*SyslogMessage, error) {
+	facility, severity, err := parsePri(m[1])
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &SyslogMessage{
+		Raw:      raw,
+		Facility: facility,
+		Severity: severity,
+		Hostname: m[3],
+		AppName:  m[4],
+		ProcID:   m[5],
+		Msg:      m[6],
+	}
+
+	// BSD 时间戳不带年份，补上当前年份以得到可用的 time.Time
+	if ts, err := time.Parse("Jan _2 15:04:05", m[2]); err == nil {
+		now := time.Now()
+		msg.Timestamp = time.Date(now.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, now.Location())
+	}
+
+	return msg, nil
+}
+
+// parseStructuredData 解析 RFC 5424 的 STRUCTURED-DATA 部分（"-" 或若干个
+// "[id key="value" ...]"），返回结构化数据以及剩余的 MSG 文本。
+func parseStructuredData(s string) (map[string]map[string]string, string) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return nil, strings.TrimSpace(strings.TrimPrefix(s, "-"))
+	}
+
+	sd := make(map[string]map[string]string)
+	loc := sdElementRe.FindAllStringSubmatchIndex(s, -1)
+	if loc == nil {
+		return nil, s
+	}
+
+	end := 0
+	for _, idx := range loc {
+		if idx[0] != end {
+			// 结构化数据元素之间出现了意料之外的内容，说明元素段已结束
+			break
+		}
+		id := s[idx[2]:idx[3]]
+		params := s[idx[4]:idx[5]]
+		kv := make(map[string]string)
+		for _, p := range sdParamRe.FindAllStringSubmatch(params, -1) {
+			kv[p[1]] = p[2]
+		}
+		sd[id] = kv
+		end = idx[1]
+	}
+
+	return sd, strings.TrimSpace(s[end:])
+}
+
+func nilDash(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// ToVars 将解析后的消息展开为规则引擎可用的命名变量，
+// 例如 $hostname、$severity、$sd.origin.ip。
+func (m *SyslogMessage) ToVars() map[string]string {
+	vars := map[string]string{
+		"facility":  strconv.Itoa(m.Facility),
+		"severity":  strconv.Itoa(m.Severity),
+		"hostname":  m.Hostname,
+		"appname":   m.AppName,
+		"procid":    m.ProcID,
+		"msgid":     m.MsgID,
+		"msg":       m.Msg,
+		"raw":       m.Raw,
+	}
+	if !m.Timestamp.IsZero() {
+		vars["timestamp"] = m.Timestamp.Format(time.RFC3339)
+	}
+	for id, kv := range m.StructuredData {
+		for k, v := range kv {
+			vars[fmt.Sprintf("sd.%s.%s", id, k)] = v
+		}
+	}
+	return vars
+}
+
+// DefaultMaxMessageSize 是未在配置中显式指定 maxmessagesize 时使用的
+// 默认单条消息大小上限，防止未限定长度的行或损坏的 octet 长度前缀
+// 造成无界内存占用。
+const DefaultMaxMessageSize = 1 << 20 // 1 MiB
+
+// newFrameScanner 构造一个按给定组帧模式切分消息的 bufio.Scanner：
+// newline 模式按 '\n' 分隔，octet 模式按 RFC 6587 "<len> <msg>" 解析。
+// 单条消息（含 octet 帧的长度前缀）不超过 maxMessageSize 字节，超出时
+// Scan 返回 false 并通过 Err() 报告错误，而不是无界分配内存。
+func newFrameScanner(r io.Reader, framing string, maxMessageSize int) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	if framing == FramingOctet {
+		scanner.Split(splitOctetFrames(maxMessageSize))
+	} else {
+		scanner.Split(bufio.ScanLines)
+	}
+	return scanner
+}
+
+// splitOctetFrames 返回一个按 RFC 6587 "<len> <msg>" 规则切分八位字节
+// 计数帧的 bufio.SplitFunc。长度前缀非法、为负或超过 maxMessageSize 时
+// 返回错误，调用方不会对不可信的长度盲目分配内存。
+func splitOctetFrames(maxMessageSize int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		sp := bytes.IndexByte(data, ' ')
+		if sp < 0 {
+			if atEOF && len(data) > 0 {
+				return 0, nil, fmt.Errorf("incomplete octet-count frame: %q", data)
+			}
+			if len(data) > 20 {
+				return 0, nil, fmt.Errorf("octet-count frame length prefix too long")
+			}
+			return 0, nil, nil
+		}
+
+		n, err := strconv.Atoi(string(data[:sp]))
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid octet-count frame length %q: %v", data[:sp], err)
+		}
+		if n < 0 || n > maxMessageSize {
+			return 0, nil, fmt.Errorf("octet-count frame length %d out of range (max %d)", n, maxMessageSize)
+		}
+
+		total := sp + 1 + n
+		if len(data) < total {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		return total, data[sp+1 : total], nil
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import "testing"
+
+func TestParseSyslogMessageRFC5424(t *testing.T) {
+	raw := `<34>1 2026-07-26T10:00:00.000Z mymachine.example.com su - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"] An application event log entry`
+
+	msg, err := ParseSyslogMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseSyslogMessage returned error: %v", err)
+	}
+
+	if msg.Facility != 4 || msg.Severity != 2 {
+		t.Errorf("facility/severity = %d/%d, want 4/2", msg.Facility, msg.Severity)
+	}
+	if msg.Hostname != "mymachine.example.com" {
+		t.Errorf("hostname = %q, want %q", msg.Hostname, "mymachine.example.com")
+	}
+	if msg.AppName != "su" {
+		t.Errorf("appname = %q, want %q", msg.AppName, "su")
+	}
+	if msg.MsgID != "ID47" {
+		t.Errorf("msgid = %q, want %q", msg.MsgID, "ID47")
+	}
+	if msg.Msg != "An application event log entry" {
+		t.Errorf("msg = %q, want %q", msg.Msg, "An application event log entry")
+	}
+
+	sd, ok := msg.StructuredData["exampleSDID@32473"]
+	if !ok {
+		t.Fatalf("expected structured data element %q, got %v", "exampleSDID@32473", msg.StructuredData)
+	}
+	if sd["iut"] != "3" {
+		t.Errorf("sd iut = %q, want %q", sd["iut"], "3")
+	}
+}
+
+func TestParseSyslogMessageRFC3164(t *testing.T) {
+	raw := `<13>Oct 11 22:14:15 mymachine su: 'su root' failed for user on /dev/pts/8`
+
+	msg, err := ParseSyslogMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseSyslogMessage returned error: %v", err)
+	}
+	if msg.Facility != 1 || msg.Severity != 5 {
+		t.Errorf("facility/severity = %d/%d, want 1/5", msg.Facility, msg.Severity)
+	}
+	if msg.Hostname != "mymachine" {
+		t.Errorf("hostname = %q, want %q", msg.Hostname, "mymachine")
+	}
+	if msg.AppName != "su" {
+		t.Errorf("appname = %q, want %q", msg.AppName, "su")
+	}
+}
+
+func TestParseSyslogMessageUnrecognized(t *testing.T) {
+	if _, err := ParseSyslogMessage("not a syslog message"); err == nil {
+		t.Errorf("expected error for unrecognized format, got nil")
+	}
+}
+
+func TestToVarsExposesStructuredDataWithEnterpriseNumber(t *testing.T) {
+	raw := `<34>1 2026-07-26T10:00:00.000Z mymachine.example.com su - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event log entry`
+
+	msg, err := ParseSyslogMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseSyslogMessage returned error: %v", err)
+	}
+
+	vars := msg.ToVars()
+	if got := vars["sd.exampleSDID@32473.iut"]; got != "3" {
+		t.Errorf("vars[%q] = %q, want %q", "sd.exampleSDID@32473.iut", got, "3")
+	}
+}
+
+func TestSplitOctetFramesRejectsOversizedLength(t *testing.T) {
+	split := splitOctetFrames(16)
+	data := []byte("1000000 payload that would be huge")
+	_, _, err := split(data, true)
+	if err == nil {
+		t.Errorf("expected error for frame length exceeding maxMessageSize, got nil")
+	}
+}
+
+func TestSplitOctetFramesRejectsNegativeLength(t *testing.T) {
+	split := splitOctetFrames(1024)
+	data := []byte("-1 payload")
+	_, _, err := split(data, true)
+	if err == nil {
+		t.Errorf("expected error for negative frame length, got nil")
+	}
+}
+
+func TestSplitOctetFramesParsesCompleteFrame(t *testing.T) {
+	split := splitOctetFrames(1024)
+	data := []byte("5 hello and more")
+	advance, token, err := split(data, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(token) != "hello" {
+		t.Errorf("token = %q, want %q", token, "hello")
+	}
+	if advance != len("5 hello") {
+		t.Errorf("advance = %d, want %d", advance, len("5 hello"))
+	}
+}
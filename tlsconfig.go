@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+var tlsVersionByName = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// buildTLSConfig 根据配置构造 RFC 5425（tls/tls+octet 模式）所需的 *tls.Config，
+// 支持指定最低 TLS 版本、密码套件，以及通过 tlsclientcafile 启用双向认证。
+func buildTLSConfig(config Config) (*tls.Config, error) {
+	if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+		return nil, fmt.Errorf("tlscertfile and tlskeyfile are required for mod %q", config.Mod)
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate/key: %v", err)
+	}
+
+	minVersion := tls.VersionTLS12
+	if config.TLSMinVersion != "" {
+		v, ok := tlsVersionByName[config.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tlsminversion %q", config.TLSMinVersion)
+		}
+		minVersion = int(v)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   uint16(minVersion),
+	}
+
+	if len(config.TLSCipherSuites) > 0 {
+		suites, err := resolveCipherSuites(config.TLSCipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.CipherSuites = suites
+	}
+
+	if config.TLSClientCAFile != "" {
+		caData, err := ioutil.ReadFile(config.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tlsclientcafile: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("failed to parse any certificates from tlsclientcafile %q", config.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// resolveCipherSuites 把配置中的密码套件名称（如 "TLS_AES_128_GCM_SHA256"）
+// 解析为 crypto/tls 的套件 ID 列表。
+func resolveCipherSuites(names []string) ([]uint16, error) {
+	byName := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		byName[suite.Name] = suite.ID
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		byName[suite.Name] = suite.ID
+	}
+
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS cipher suite %q", name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}